@@ -0,0 +1,89 @@
+package slogemail
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// StackTraceAttachment is a GetAttachmentsFunc that captures the current
+// goroutine stack via runtime.Stack and attaches it as stacktrace.txt, but
+// only for records at slog.LevelError or above.
+func StackTraceAttachment(ctx context.Context, r slog.Record, logOutput string) []Attachment {
+	if r.Level < slog.LevelError {
+		return nil
+	}
+
+	buf := make([]byte, 1<<16)
+	n := runtime.Stack(buf, false)
+
+	return []Attachment{{
+		Name:        "stacktrace.txt",
+		ContentType: "text/plain",
+		Data:        buf[:n],
+	}}
+}
+
+// RingBuffer holds the last N rendered log lines seen by an EmailHandler,
+// for attaching to alert emails via RecentLogsAttachment. It is safe for
+// concurrent use.
+type RingBuffer struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	size  int
+}
+
+// NewRingBuffer creates a RingBuffer holding up to capacity lines.
+func NewRingBuffer(capacity int) *RingBuffer {
+	return &RingBuffer{lines: make([]string, capacity)}
+}
+
+// Add appends line, evicting the oldest line once the buffer is full.
+func (b *RingBuffer) Add(line string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.lines) == 0 {
+		return
+	}
+
+	b.lines[b.next] = line
+	b.next = (b.next + 1) % len(b.lines)
+	if b.size < len(b.lines) {
+		b.size++
+	}
+}
+
+// Lines returns the buffered lines in the order they were added, oldest
+// first.
+func (b *RingBuffer) Lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.size == 0 {
+		return nil
+	}
+
+	out := make([]string, b.size)
+	start := (b.next - b.size + len(b.lines)) % len(b.lines)
+	for i := 0; i < b.size; i++ {
+		out[i] = b.lines[(start+i)%len(b.lines)]
+	}
+	return out
+}
+
+// RecentLogsAttachment builds a GetAttachmentsFunc that attaches the
+// contents of ring as recent-logs.txt. Pass the same *RingBuffer to
+// EmailHandlerOpts.RecentLogsRing so the handler keeps it filled.
+func RecentLogsAttachment(ring *RingBuffer) GetAttachmentsFunc {
+	return func(ctx context.Context, r slog.Record, logOutput string) []Attachment {
+		return []Attachment{{
+			Name:        "recent-logs.txt",
+			ContentType: "text/plain",
+			Data:        []byte(strings.Join(ring.Lines(), "")),
+		}}
+	}
+}
@@ -0,0 +1,86 @@
+package slogemail
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStackTraceAttachmentOnlyAttachesAtErrorLevel(t *testing.T) {
+	warn := slog.NewRecord(time.Now(), slog.LevelWarn, "careful", 0)
+	if got := StackTraceAttachment(context.Background(), warn, ""); got != nil {
+		t.Fatalf("expected no attachment below LevelError, got %v", got)
+	}
+
+	errRec := slog.NewRecord(time.Now(), slog.LevelError, "boom", 0)
+	got := StackTraceAttachment(context.Background(), errRec, "")
+	if len(got) != 1 {
+		t.Fatalf("expected exactly one attachment, got %d", len(got))
+	}
+	if got[0].Name != "stacktrace.txt" || len(got[0].Data) == 0 {
+		t.Fatalf("unexpected attachment: %+v", got[0])
+	}
+}
+
+func TestRingBufferWraparound(t *testing.T) {
+	b := NewRingBuffer(3)
+
+	b.Add("a")
+	b.Add("b")
+	if got := b.Lines(); !slicesEqual(got, []string{"a", "b"}) {
+		t.Fatalf("unexpected lines before full: %v", got)
+	}
+
+	b.Add("c")
+	b.Add("d") // evicts "a"
+	if got := b.Lines(); !slicesEqual(got, []string{"b", "c", "d"}) {
+		t.Fatalf("unexpected lines after wraparound: %v", got)
+	}
+
+	b.Add("e") // evicts "b"
+	if got := b.Lines(); !slicesEqual(got, []string{"c", "d", "e"}) {
+		t.Fatalf("unexpected lines after second wraparound: %v", got)
+	}
+}
+
+func TestRingBufferZeroCapacityIsANoop(t *testing.T) {
+	b := NewRingBuffer(0)
+	b.Add("a")
+	if got := b.Lines(); len(got) != 0 {
+		t.Fatalf("expected no lines from a zero-capacity ring, got %v", got)
+	}
+}
+
+func TestRecentLogsAttachmentJoinsRingContents(t *testing.T) {
+	ring := NewRingBuffer(2)
+	ring.Add("line one\n")
+	ring.Add("line two\n")
+	ring.Add("line three\n") // evicts "line one"
+
+	attach := RecentLogsAttachment(ring)
+	got := attach(context.Background(), slog.Record{}, "")
+	if len(got) != 1 || got[0].Name != "recent-logs.txt" {
+		t.Fatalf("unexpected attachment: %+v", got)
+	}
+	body := string(got[0].Data)
+	if strings.Contains(body, "line one") {
+		t.Fatalf("expected the evicted line to be absent, got: %q", body)
+	}
+	if !strings.Contains(body, "line two") || !strings.Contains(body, "line three") {
+		t.Fatalf("expected both remaining lines, got: %q", body)
+	}
+}
+
+func slicesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
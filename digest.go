@@ -0,0 +1,238 @@
+package slogemail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// DedupeKeyFunc computes a grouping key for a log record so that repeated
+// occurrences within a digest window can be folded into a single entry.
+// By default the record's Message is used as the key.
+type DedupeKeyFunc func(r slog.Record) string
+
+// GetDigestSubjectFunc builds the subject line for a batched digest email.
+type GetDigestSubjectFunc func(ctx context.Context, entries []*DigestEntry) string
+
+// DigestEntry groups every occurrence of a record sharing the same dedupe
+// key that arrived within a single digest window. The rendered text/JSON
+// and attachments are taken from the first occurrence, matching Record.
+type DigestEntry struct {
+	rec          slog.Record
+	texts        []string
+	renderedText string
+	renderedJSON string
+	attachments  []Attachment
+}
+
+// Record returns the first occurrence of this entry's record, for use by a
+// GetDigestSubjectFunc.
+func (e *DigestEntry) Record() slog.Record {
+	return e.rec
+}
+
+// Count returns how many times a record with this entry's dedupe key
+// occurred within the digest window.
+func (e *DigestEntry) Count() int {
+	return len(e.texts)
+}
+
+// runDigestWorker accumulates records arriving on mailC for DigestWindow and
+// emits one email per window containing all distinct (by DedupeKey) records,
+// each annotated with how many times it repeated. It also flushes early if
+// MaxEmailsPerInterval is reached, so a sustained burst doesn't wait out the
+// full window before anyone is notified.
+func (h *EmailHandler) runDigestWorker() {
+	timer := time.NewTimer(h.digestWindow)
+	defer timer.Stop()
+
+	groups := make(map[string]*DigestEntry)
+	order := make([]string, 0)
+	total := 0
+	ctx := context.Background()
+
+	flush := func() {
+		if total == 0 {
+			return
+		}
+		entries := make([]*DigestEntry, 0, len(order))
+		for _, k := range order {
+			entries = append(entries, groups[k])
+		}
+		if err := h.sendDigest(ctx, entries); err != nil && h.errorHandler != nil {
+			for _, e := range entries {
+				h.errorHandler(err, e.rec)
+			}
+		}
+		groups = make(map[string]*DigestEntry)
+		order = order[:0]
+		total = 0
+	}
+
+	for {
+		select {
+		case e, ok := <-h.mailC:
+			if !ok {
+				flush()
+				return
+			}
+			ctx = e.ctx
+			key := e.rec.Message
+			if h.dedupeKey != nil {
+				key = h.dedupeKey(e.rec)
+			}
+			g, exists := groups[key]
+			if !exists {
+				g = &DigestEntry{
+					rec:          e.rec,
+					renderedText: e.renderedText,
+					renderedJSON: e.renderedJSON,
+					attachments:  e.attachments,
+				}
+				groups[key] = g
+				order = append(order, key)
+			}
+			g.texts = append(g.texts, e.text)
+			total++
+
+			if h.maxEmailsPerInterval > 0 && total >= h.maxEmailsPerInterval {
+				flush()
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(h.digestWindow)
+			}
+		case <-timer.C:
+			flush()
+			timer.Reset(h.digestWindow)
+		}
+	}
+}
+
+func (h *EmailHandler) sendDigest(ctx context.Context, entries []*DigestEntry) error {
+	var subject string
+	if h.getDigestSubject != nil {
+		subject = h.getDigestSubject(ctx, entries)
+	} else {
+		total := 0
+		for _, e := range entries {
+			total += e.Count()
+		}
+		subject = fmt.Sprintf("%s digest (%d records)", h.emailLevel.String(), total)
+	}
+
+	textBody, htmlBody, err := h.renderDigestFormattedBody(entries)
+	if err != nil {
+		return err
+	}
+
+	var attachments []Attachment
+	for _, e := range entries {
+		attachments = append(attachments, e.attachments...)
+	}
+
+	return h.transport.Send(ctx, h.fromAddr, h.toAddrs, subject, textBody, htmlBody, nil, attachments)
+}
+
+// renderDigestFormattedBody builds the digest body according to h.format,
+// rendering each entry separately through h.textTemplate/h.htmlTemplate and
+// concatenating the results, the same way renderDigestText concatenates
+// plain entries.
+func (h *EmailHandler) renderDigestFormattedBody(entries []*DigestEntry) (textBody string, htmlBody string, err error) {
+	switch h.format {
+	case FormatHTML:
+		htmlBody, err = h.renderDigestHTMLTemplate(entries)
+		return "", htmlBody, err
+	case FormatBoth:
+		textBody, err = h.renderDigestTextTemplate(entries)
+		if err != nil {
+			return "", "", err
+		}
+		htmlBody, err = h.renderDigestHTMLTemplate(entries)
+		if err != nil {
+			return "", "", err
+		}
+		return textBody, htmlBody, nil
+	default:
+		if h.json {
+			return renderDigestJSON(entries), "", nil
+		}
+		return renderDigestText(entries), "", nil
+	}
+}
+
+// renderDigestHTMLTemplate renders each entry through h.htmlTemplate and
+// joins the results, the same way renderDigestText joins plain entries.
+func (h *EmailHandler) renderDigestHTMLTemplate(entries []*DigestEntry) (string, error) {
+	var b strings.Builder
+	for _, e := range entries {
+		data := newTemplateData(e.rec, h.groups, e.renderedText, e.renderedJSON)
+		part, err := renderHTMLTemplate(h.htmlTemplate, data)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(part)
+		if n := e.Count(); n > 1 {
+			fmt.Fprintf(&b, "(repeated %d times)\n", n)
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// renderDigestTextTemplate renders each entry through h.textTemplate and
+// joins the results, the same way renderDigestText joins plain entries.
+func (h *EmailHandler) renderDigestTextTemplate(entries []*DigestEntry) (string, error) {
+	var b strings.Builder
+	for _, e := range entries {
+		data := newTemplateData(e.rec, h.groups, e.renderedText, e.renderedJSON)
+		part, err := renderTextTemplate(h.textTemplate, data)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(part)
+		if n := e.Count(); n > 1 {
+			fmt.Fprintf(&b, "(repeated %d times)\n", n)
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+func renderDigestText(entries []*DigestEntry) string {
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString(e.texts[0])
+		if n := e.Count(); n > 1 {
+			fmt.Fprintf(&b, "(repeated %d times)\n", n)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func renderDigestJSON(entries []*DigestEntry) string {
+	type digestJSONEntry struct {
+		Message string   `json:"message"`
+		Count   int      `json:"count"`
+		Records []string `json:"records"`
+	}
+
+	out := make([]digestJSONEntry, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, digestJSONEntry{
+			Message: e.rec.Message,
+			Count:   e.Count(),
+			Records: e.texts,
+		})
+	}
+
+	b, err := json.MarshalIndent(out, "", "    ")
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,105 @@
+package slogemail
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingTransport captures every Send call instead of delivering mail,
+// and signals sent after each one so tests can wait for a digest flush
+// without sleeping for a fixed duration.
+type recordingTransport struct {
+	mu    sync.Mutex
+	sends []string
+	sent  chan struct{}
+}
+
+func newRecordingTransport() *recordingTransport {
+	return &recordingTransport{sent: make(chan struct{}, 16)}
+}
+
+func (t *recordingTransport) Send(ctx context.Context, from string, to []string, subject string, textBody string, htmlBody string, headers map[string]string, attachments []Attachment) error {
+	t.mu.Lock()
+	t.sends = append(t.sends, subject+"\n"+textBody+"\n"+htmlBody)
+	t.mu.Unlock()
+	t.sent <- struct{}{}
+	return nil
+}
+
+func (t *recordingTransport) waitForSend(t2 *testing.T) string {
+	t2.Helper()
+	select {
+	case <-t.sent:
+	case <-time.After(2 * time.Second):
+		t2.Fatal("timed out waiting for digest to be sent")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sends[len(t.sends)-1]
+}
+
+// TestDigestWorkerDedupesAndCountsTotalRecords checks that repeated records
+// sharing a dedupe key collapse into one entry, and that the default
+// subject's record count is the sum of occurrences, not the number of
+// distinct entries.
+func TestDigestWorkerDedupesAndCountsTotalRecords(t *testing.T) {
+	transport := newRecordingTransport()
+	h, err := NewHandler(io.Discard, nil, transport, EmailHandlerOpts{
+		FromAddr:     "from@example.com",
+		ToAddrs:      []string{"to@example.com"},
+		Level:        slog.LevelError,
+		DigestWindow: 30 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+	defer h.Shutdown(context.Background())
+
+	logger := slog.New(h)
+	logger.Error("boom")
+	logger.Error("boom")
+	logger.Error("boom")
+	logger.Error("other error")
+
+	body := transport.waitForSend(t)
+	if !strings.Contains(body, "ERROR digest (4 records)") {
+		t.Fatalf("expected subject to report 4 total records, got: %q", body)
+	}
+	if !strings.Contains(body, "repeated 3 times") {
+		t.Fatalf("expected the repeated entry to be annotated, got: %q", body)
+	}
+}
+
+// TestDigestWorkerFlushesEarlyOnMaxEmailsPerInterval checks that hitting
+// MaxEmailsPerInterval flushes the digest immediately instead of waiting
+// out the full DigestWindow.
+func TestDigestWorkerFlushesEarlyOnMaxEmailsPerInterval(t *testing.T) {
+	transport := newRecordingTransport()
+	h, err := NewHandler(io.Discard, nil, transport, EmailHandlerOpts{
+		FromAddr:             "from@example.com",
+		ToAddrs:              []string{"to@example.com"},
+		Level:                slog.LevelError,
+		DigestWindow:         time.Hour,
+		MaxEmailsPerInterval: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+	defer h.Shutdown(context.Background())
+
+	logger := slog.New(h)
+	logger.Error("first")
+	logger.Error("second")
+
+	// With DigestWindow set to an hour, only the early flush could have
+	// produced a send within the test's timeout.
+	body := transport.waitForSend(t)
+	if !strings.Contains(body, "ERROR digest (2 records)") {
+		t.Fatalf("expected early flush to report 2 records, got: %q", body)
+	}
+}
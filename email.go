@@ -1,36 +1,42 @@
 package slogemail
 
 import (
-	"context"
+	"bytes"
 
 	"github.com/wneessen/go-mail"
 )
 
-type Mailer struct {
-	client *mail.Client
-}
+// buildMailMsg assembles a go-mail message shared by every Transport
+// implementation that needs one (SMTP, sendmail).
+func buildMailMsg(from string, to []string, subject string, textBody string, htmlBody string, headers map[string]string, attachments []Attachment) (*mail.Msg, error) {
+	msg := mail.NewMsg()
+	if err := msg.From(from); err != nil {
+		return nil, err
+	}
 
-func NewMailer(smtpHost string, smtpPort int, username string, password string) (*Mailer, error) {
-	c, err := mail.NewClient(smtpHost, mail.WithPort(smtpPort), mail.WithUsername(username), mail.WithPassword(password))
-	if err != nil {
+	if err := msg.To(to...); err != nil {
 		return nil, err
 	}
 
-	return &Mailer{client: c}, nil
-}
+	msg.Subject(subject)
 
-func (m *Mailer) SendPlaintextMessage(ctx context.Context, from string, to []string, subject string, body string) error {
-	msg := mail.NewMsg()
-	if err := msg.From(from); err != nil {
-		return err
+	switch {
+	case textBody != "" && htmlBody != "":
+		msg.SetBodyString(mail.TypeTextPlain, textBody)
+		msg.AddAlternativeString(mail.TypeTextHTML, htmlBody)
+	case htmlBody != "":
+		msg.SetBodyString(mail.TypeTextHTML, htmlBody)
+	default:
+		msg.SetBodyString(mail.TypeTextPlain, textBody)
 	}
 
-	if err := msg.To(to...); err != nil {
-		return err
+	for k, v := range headers {
+		msg.SetGenHeader(mail.Header(k), v)
 	}
 
-	msg.Subject(subject)
-	msg.SetBodyString(mail.TypeTextPlain, body)
+	for _, a := range attachments {
+		msg.AttachReader(a.Name, bytes.NewReader(a.Data), mail.WithFileContentType(mail.ContentType(a.ContentType)))
+	}
 
-	return m.client.DialAndSendWithContext(ctx, msg)
+	return msg, nil
 }
@@ -5,9 +5,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	htmltemplate "html/template"
 	"io"
 	"log/slog"
 	"sync"
+	texttemplate "text/template"
+	"time"
 )
 
 // SendEmailFunc describes function that user has to implement to fully control mailing process
@@ -21,6 +24,12 @@ type GetSubjectFunc func(ctx context.Context, r slog.Record, logOutput string) s
 // from slog's standard text or json handler and returns a body for a letter
 type GetBodyFunc func(ctx context.Context, r slog.Record, logOutput string) string
 
+// GetAttachmentsFunc is a function that accepts an slog record and rendered
+// output and returns the files or blobs to attach to its email. It's called
+// only for records at or above EmailHandlerOpts.Level. StackTraceAttachment
+// and RecentLogsAttachment are ready-made implementations.
+type GetAttachmentsFunc func(ctx context.Context, r slog.Record, logOutput string) []Attachment
+
 // SMTPConnectionInfo cotains information sufficient to connect to a generic SMTP server
 type SMTPConnectionInfo struct {
 	// Host
@@ -31,12 +40,32 @@ type SMTPConnectionInfo struct {
 	Username string
 	// Password
 	Password string
+	// MaxConns caps how many persistent SMTP connections the transport
+	// pool keeps open to Host at once. Default: 1.
+	MaxConns int
+	// IdleTimeout is how long a pooled connection may sit unused before
+	// it is closed instead of reused. Default: 5 minutes.
+	IdleTimeout time.Duration
+	// SendTimeout bounds a whole send, including connection checkout,
+	// every retry and the backoff between them.
+	// Zero means no additional deadline beyond the caller's context.
+	SendTimeout time.Duration
+	// MaxRetries is how many additional attempts are made after a
+	// transient send failure (network error, 4xx SMTP reply), with
+	// exponential backoff between attempts. Default: 0 (no retries).
+	MaxRetries int
+	// HelloHostname overrides the hostname sent in the SMTP HELO/EHLO
+	// greeting. Default: let go-mail choose.
+	HelloHostname string
 }
 
 type logEmail struct {
-	ctx  context.Context
-	rec  slog.Record
-	text string
+	ctx          context.Context
+	rec          slog.Record
+	text         string
+	renderedText string
+	renderedJSON string
+	attachments  []Attachment
 }
 
 // EmailHandlerOpts contains options specific to EmailHandler
@@ -59,25 +88,84 @@ type EmailHandlerOpts struct {
 	// QueueSize specifies how many records can be queued before logger will have to actually wait for them to be sent
 	// Default: 1
 	QueueSize int
+	// DigestWindow, if non-zero, turns on batch-digest mode: records at or
+	// above Level are accumulated for this long and sent as a single email
+	// instead of one email per record. Useful for surviving bursts of errors
+	// during an outage without flooding the mailbox.
+	DigestWindow time.Duration
+	// MaxEmailsPerInterval caps how many records can accumulate in a single
+	// digest window before it is flushed early. Zero means no early flush.
+	MaxEmailsPerInterval int
+	// DedupeKey groups records within a digest window so repeats collapse
+	// into one entry annotated with a repeat count. Defaults to the record's
+	// Message when unset.
+	DedupeKey DedupeKeyFunc
+	// GetDigestSubject is a user-defined function for making a custom subject
+	// for digest emails. By default it summarizes the level and the total
+	// number of records in the digest, repeats included.
+	GetDigestSubject GetDigestSubjectFunc
+	// Format selects whether emails are rendered as plaintext, HTML, or
+	// both. Defaults to FormatPlain. Ignored for single-record emails when
+	// GetBody is set; digest emails always use Format and never call GetBody.
+	Format EmailFormat
+	// HTMLTemplate renders the HTML part of the email body when Format is
+	// FormatHTML or FormatBoth.
+	HTMLTemplate *htmltemplate.Template
+	// TextTemplate renders the plaintext part of the email body when
+	// Format is FormatBoth.
+	TextTemplate *texttemplate.Template
+	// ErrorHandler is called when a record's email ultimately fails to
+	// send (retries exhausted or a permanent failure). If unset, the
+	// error is silently dropped, same as today.
+	ErrorHandler func(err error, r slog.Record)
+	// GetAttachments builds extra files to attach to emails for records
+	// at or above Level, e.g. a captured stack trace or recent log lines.
+	GetAttachments GetAttachmentsFunc
+	// RecentLogsRing, if set, receives every rendered log line the
+	// handler processes (regardless of Level) so that RecentLogsAttachment
+	// can later attach the most recent ones to an alert email.
+	RecentLogsRing *RingBuffer
 }
 
 // EmailHandler is a log/slog compatible handler that writes log records in text or json to user-provided io.Writer
 // and also emails records with defined levels to specified addresses.
 type EmailHandler struct {
-	enabled       bool
-	baseHandler   slog.Handler
-	buf           *bytes.Buffer
-	out           io.Writer
-	mu            sync.Mutex
-	emailLevel    slog.Level
-	customSend    SendEmailFunc
-	getSubject    GetSubjectFunc
-	getBody       GetBodyFunc
-	fromAddr      string
-	toAddrs       []string
-	json          bool
-	defaultMailer *Mailer
-	mailC         chan logEmail
+	enabled     bool
+	baseHandler slog.Handler
+	buf         *bytes.Buffer
+	out         io.Writer
+	mu          sync.Mutex
+	emailLevel  slog.Level
+	customSend  SendEmailFunc
+	getSubject  GetSubjectFunc
+	getBody     GetBodyFunc
+	fromAddr    string
+	toAddrs     []string
+	json        bool
+	transport   Transport
+	mailC       chan logEmail
+	groups      []string
+	wg          sync.WaitGroup
+
+	// altHandler renders the same record in the format (text/JSON) that
+	// json does not already cover, so RenderedText/RenderedJSON are always
+	// both available to templates regardless of the configured format.
+	altHandler slog.Handler
+	altBuf     *bytes.Buffer
+
+	digestWindow         time.Duration
+	maxEmailsPerInterval int
+	dedupeKey            DedupeKeyFunc
+	getDigestSubject     GetDigestSubjectFunc
+
+	format       EmailFormat
+	htmlTemplate *htmltemplate.Template
+	textTemplate *texttemplate.Template
+
+	errorHandler func(err error, r slog.Record)
+
+	getAttachments GetAttachmentsFunc
+	recentLogsRing *RingBuffer
 }
 
 // NewCustomHandler creates a new handler that prints logs to supplied io.Writer and
@@ -101,8 +189,9 @@ func NewCustomHandler(w io.Writer, opts *slog.HandlerOptions, f SendEmailFunc, j
 }
 
 // NewHandler creates a new handler than prints log to supplied io.Writer and
-// also sends them to a simple SMTP server as an email
-func NewHandler(w io.Writer, opts *slog.HandlerOptions, emailOpts EmailHandlerOpts) (*EmailHandler, func(), error) {
+// also sends them to specified addresses as an email via transport. Call
+// Shutdown on the returned handler to stop it cleanly.
+func NewHandler(w io.Writer, opts *slog.HandlerOptions, transport Transport, emailOpts EmailHandlerOpts) (*EmailHandler, error) {
 	buf := new(bytes.Buffer)
 	var baseHandler slog.Handler
 	if emailOpts.JSON {
@@ -111,50 +200,114 @@ func NewHandler(w io.Writer, opts *slog.HandlerOptions, emailOpts EmailHandlerOp
 		baseHandler = slog.NewTextHandler(buf, opts)
 	}
 
+	altBuf := new(bytes.Buffer)
+	var altHandler slog.Handler
+	if emailOpts.JSON {
+		altHandler = slog.NewTextHandler(altBuf, opts)
+	} else {
+		altHandler = slog.NewJSONHandler(altBuf, opts)
+	}
+
 	if emailOpts.QueueSize == 0 {
 		emailOpts.QueueSize = 1
 	}
 
 	handler := &EmailHandler{
-		enabled:     true,
-		baseHandler: baseHandler,
-		buf:         buf,
-		out:         w,
-		emailLevel:  emailOpts.Level,
-		fromAddr:    emailOpts.FromAddr,
-		toAddrs:     emailOpts.ToAddrs,
-		getSubject:  emailOpts.GetSubject,
-		getBody:     emailOpts.GetBody,
-		json:        emailOpts.JSON,
+		enabled:              true,
+		baseHandler:          baseHandler,
+		buf:                  buf,
+		out:                  w,
+		emailLevel:           emailOpts.Level,
+		fromAddr:             emailOpts.FromAddr,
+		toAddrs:              emailOpts.ToAddrs,
+		getSubject:           emailOpts.GetSubject,
+		getBody:              emailOpts.GetBody,
+		json:                 emailOpts.JSON,
+		transport:            transport,
+		altHandler:           altHandler,
+		altBuf:               altBuf,
+		digestWindow:         emailOpts.DigestWindow,
+		maxEmailsPerInterval: emailOpts.MaxEmailsPerInterval,
+		dedupeKey:            emailOpts.DedupeKey,
+		getDigestSubject:     emailOpts.GetDigestSubject,
+		format:               emailOpts.Format,
+		htmlTemplate:         emailOpts.HTMLTemplate,
+		textTemplate:         emailOpts.TextTemplate,
+		errorHandler:         emailOpts.ErrorHandler,
+		getAttachments:       emailOpts.GetAttachments,
+		recentLogsRing:       emailOpts.RecentLogsRing,
+	}
+
+	handler.mailC = make(chan logEmail, emailOpts.QueueSize)
+
+	handler.wg.Add(1)
+	if handler.digestWindow > 0 {
+		go func() {
+			defer handler.wg.Done()
+			handler.runDigestWorker()
+		}()
+	} else {
+		go func() {
+			defer handler.wg.Done()
+			for e := range handler.mailC {
+				if err := handler.send(e.ctx, e.rec, e.text, e.renderedText, e.renderedJSON, e.attachments); err != nil && handler.errorHandler != nil {
+					handler.errorHandler(err, e.rec)
+				}
+			}
+		}()
 	}
 
-	mailer, err := NewMailer(emailOpts.ConnectionInfo.Host, emailOpts.ConnectionInfo.Port,
-		emailOpts.ConnectionInfo.Username, emailOpts.ConnectionInfo.Password)
+	return handler, nil
+}
+
+// NewSMTPHandler is sugar over NewHandler for the common case of relaying
+// through a plain SMTP server: it builds the SMTP Transport from
+// emailOpts.ConnectionInfo and passes it along.
+func NewSMTPHandler(w io.Writer, opts *slog.HandlerOptions, emailOpts EmailHandlerOpts) (*EmailHandler, error) {
+	transport, err := NewSMTPTransport(emailOpts.ConnectionInfo)
 	if err != nil {
-		return handler, nil, err
+		return nil, err
 	}
-	handler.defaultMailer = mailer
 
-	handler.mailC = make(chan logEmail, emailOpts.QueueSize)
+	return NewHandler(w, opts, transport, emailOpts)
+}
 
+// Shutdown stops the handler from accepting new records, then drains and
+// waits for any already-queued emails to finish sending through the worker
+// goroutine. If ctx is done before the drain completes, Shutdown returns
+// ctx.Err() without waiting further; the worker keeps running in the
+// background and finishes the drain on its own.
+func (h *EmailHandler) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	if !h.enabled {
+		h.mu.Unlock()
+		return nil
+	}
+	h.enabled = false
+	if h.mailC != nil {
+		close(h.mailC)
+	}
+	h.mu.Unlock()
+
+	drained := make(chan struct{})
 	go func() {
-		for e := range handler.mailC {
-			handler.send(e.ctx, e.rec, e.text)
-		}
+		h.wg.Wait()
+		close(drained)
 	}()
 
-	closeFunc := func() {
-		handler.mu.Lock()
-		defer handler.mu.Unlock()
-		handler.enabled = false
-		close(handler.mailC)
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-
-	return handler, closeFunc, nil
 }
 
 func (h *EmailHandler) Enabled(ctx context.Context, level slog.Level) bool {
-	return h.enabled && h.baseHandler.Enabled(ctx, level)
+	h.mu.Lock()
+	enabled := h.enabled
+	h.mu.Unlock()
+	return enabled && h.baseHandler.Enabled(ctx, level)
 }
 
 func prettifyJSON(str string) (string, error) {
@@ -168,6 +321,9 @@ func prettifyJSON(str string) (string, error) {
 func (h *EmailHandler) Handle(ctx context.Context, r slog.Record) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	if !h.enabled {
+		return nil
+	}
 	if err := h.baseHandler.Handle(ctx, r); err != nil {
 		return err
 	}
@@ -178,23 +334,65 @@ func (h *EmailHandler) Handle(ctx context.Context, r slog.Record) error {
 		return err
 	}
 
+	if h.recentLogsRing != nil {
+		h.recentLogsRing.Add(text)
+	}
+
 	if r.Level >= h.emailLevel {
 		if h.customSend != nil {
 			return h.customSend(ctx, r, text)
 		}
+
+		renderedText, renderedJSON, err := h.renderAlt(ctx, r, text)
+		if err != nil {
+			return err
+		}
+
+		var attachments []Attachment
+		if h.getAttachments != nil {
+			attachments = h.getAttachments(ctx, r, text)
+		}
+
 		h.mailC <- logEmail{
-			ctx:  ctx,
-			rec:  r,
-			text: text,
+			ctx:          ctx,
+			rec:          r,
+			text:         text,
+			renderedText: renderedText,
+			renderedJSON: renderedJSON,
+			attachments:  attachments,
 		}
 	}
 
 	return nil
 }
 
-func (h *EmailHandler) send(ctx context.Context, r slog.Record, text string) error {
+// renderAlt renders r in whichever of text/JSON the configured base format
+// does not already cover, so templates can always access both via
+// TemplateData.RenderedText and TemplateData.RenderedJSON.
+func (h *EmailHandler) renderAlt(ctx context.Context, r slog.Record, text string) (renderedText string, renderedJSON string, err error) {
+	if err := h.altHandler.Handle(ctx, r); err != nil {
+		return "", "", err
+	}
+	alt := h.altBuf.String()
+	h.altBuf.Reset()
+
+	if h.json {
+		pretty, err := prettifyJSON(text)
+		if err != nil {
+			return "", "", err
+		}
+		return alt, pretty, nil
+	}
+
+	pretty, err := prettifyJSON(alt)
+	if err != nil {
+		return "", "", err
+	}
+	return text, pretty, nil
+}
 
-	var subject, body string
+func (h *EmailHandler) send(ctx context.Context, r slog.Record, text string, renderedText string, renderedJSON string, attachments []Attachment) error {
+	var subject string
 	if h.getSubject != nil {
 		subject = h.getSubject(ctx, r, text)
 	} else {
@@ -202,26 +400,52 @@ func (h *EmailHandler) send(ctx context.Context, r slog.Record, text string) err
 	}
 
 	if h.getBody != nil {
-		body = h.getBody(ctx, r, text)
-	} else {
+		body := h.getBody(ctx, r, text)
+		return h.transport.Send(ctx, h.fromAddr, h.toAddrs, subject, body, "", nil, attachments)
+	}
+
+	textBody, htmlBody, err := h.renderFormattedBody(r, text, renderedText, renderedJSON)
+	if err != nil {
+		return err
+	}
+
+	return h.transport.Send(ctx, h.fromAddr, h.toAddrs, subject, textBody, htmlBody, nil, attachments)
+}
+
+// renderFormattedBody builds the plaintext and/or HTML body according to
+// h.format, using h.textTemplate/h.htmlTemplate when templates are in play.
+func (h *EmailHandler) renderFormattedBody(r slog.Record, text string, renderedText string, renderedJSON string) (textBody string, htmlBody string, err error) {
+	switch h.format {
+	case FormatHTML:
+		data := newTemplateData(r, h.groups, renderedText, renderedJSON)
+		htmlBody, err = renderHTMLTemplate(h.htmlTemplate, data)
+		return "", htmlBody, err
+	case FormatBoth:
+		data := newTemplateData(r, h.groups, renderedText, renderedJSON)
+		textBody, err = renderTextTemplate(h.textTemplate, data)
+		if err != nil {
+			return "", "", err
+		}
+		htmlBody, err = renderHTMLTemplate(h.htmlTemplate, data)
+		if err != nil {
+			return "", "", err
+		}
+		return textBody, htmlBody, nil
+	default:
 		if h.json {
-			var err error
-			body, err = prettifyJSON(text)
-			if err != nil {
-				return err
-			}
-		} else {
-			body = text
+			return renderedJSON, "", nil
 		}
+		return text, "", nil
 	}
-
-	return h.defaultMailer.SendPlaintextMessage(ctx, h.fromAddr, h.toAddrs, subject, body)
 }
 
 func (h *EmailHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	h.baseHandler = h.baseHandler.WithAttrs(attrs)
+	if h.altHandler != nil {
+		h.altHandler = h.altHandler.WithAttrs(attrs)
+	}
 	return h
 }
 
@@ -229,9 +453,9 @@ func (h *EmailHandler) WithGroup(name string) slog.Handler {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	h.baseHandler = h.baseHandler.WithGroup(name)
+	if h.altHandler != nil {
+		h.altHandler = h.altHandler.WithGroup(name)
+	}
+	h.groups = append(h.groups, name)
 	return h
 }
-
-func (h *EmailHandler) sendEmailDefault(ctx context.Context, from string, to []string, subject string, body string) error {
-	return h.defaultMailer.SendPlaintextMessage(ctx, from, to, subject, body)
-}
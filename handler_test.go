@@ -0,0 +1,76 @@
+package slogemail
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeTransport struct{}
+
+func (fakeTransport) Send(ctx context.Context, from string, to []string, subject string, textBody string, htmlBody string, headers map[string]string, attachments []Attachment) error {
+	return nil
+}
+
+// TestEmailHandlerEnabledDuringShutdown exercises Enabled, Handle, and
+// Shutdown concurrently; run with -race, it catches regressions of the
+// h.enabled race between Enabled and Shutdown/Handle.
+func TestEmailHandlerEnabledDuringShutdown(t *testing.T) {
+	h, err := NewHandler(io.Discard, nil, fakeTransport{}, EmailHandlerOpts{
+		FromAddr: "from@example.com",
+		ToAddrs:  []string{"to@example.com"},
+		Level:    slog.LevelError,
+	})
+	if err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+
+	logger := slog.New(h)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				h.Enabled(context.Background(), slog.LevelError)
+			}
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				logger.Error("boom")
+			}
+		}
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := h.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	close(stop)
+	wg.Wait()
+
+	if err := h.Shutdown(context.Background()); err != nil {
+		t.Fatalf("second Shutdown: %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package slogemail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// MailgunTransport sends mail through the Mailgun HTTP API, for
+// environments that don't want to run or relay through an SMTP server.
+type MailgunTransport struct {
+	// Host is the Mailgun API base, e.g. "https://api.mailgun.net".
+	Host string
+	// Domain is the sending domain configured in Mailgun.
+	Domain string
+	// APIKey is the Mailgun private API key.
+	APIKey string
+	// HTTPClient is used to make requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// NewMailgunTransport builds a Transport backed by the Mailgun HTTP API.
+func NewMailgunTransport(host, domain, apiKey string) *MailgunTransport {
+	return &MailgunTransport{Host: host, Domain: domain, APIKey: apiKey}
+}
+
+func (t *MailgunTransport) httpClient() *http.Client {
+	if t.HTTPClient != nil {
+		return t.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (t *MailgunTransport) Send(ctx context.Context, from string, to []string, subject string, textBody string, htmlBody string, headers map[string]string, attachments []Attachment) error {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	if err := w.WriteField("from", from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := w.WriteField("to", addr); err != nil {
+			return err
+		}
+	}
+	if err := w.WriteField("subject", subject); err != nil {
+		return err
+	}
+	if textBody != "" {
+		if err := w.WriteField("text", textBody); err != nil {
+			return err
+		}
+	}
+	if htmlBody != "" {
+		if err := w.WriteField("html", htmlBody); err != nil {
+			return err
+		}
+	}
+	for k, v := range headers {
+		if err := w.WriteField("h:"+k, v); err != nil {
+			return err
+		}
+	}
+	for _, a := range attachments {
+		fw, err := w.CreateFormFile("attachment", a.Name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(a.Data); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/v3/%s/messages", strings.TrimRight(t.Host, "/"), t.Domain)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &buf)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth("api", t.APIKey)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	resp, err := t.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slogemail: mailgun API returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package slogemail
+
+import (
+	"context"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestMailgunTransportSendBuildsMultipartRequest checks that Send posts the
+// expected fields and attachment to Mailgun's messages endpoint.
+func TestMailgunTransportSendBuildsMultipartRequest(t *testing.T) {
+	var gotPath string
+	var gotUser, gotPass string
+	values := map[string][]string{}
+	var attachmentName string
+	var attachmentBody string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotUser, gotPass, _ = r.BasicAuth()
+
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			t.Fatalf("ParseMediaType: %v", err)
+		}
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			if part.FileName() != "" {
+				attachmentName = part.FileName()
+				buf := make([]byte, 64)
+				n, _ := part.Read(buf)
+				attachmentBody = string(buf[:n])
+				continue
+			}
+			buf := make([]byte, 256)
+			n, _ := part.Read(buf)
+			values[part.FormName()] = append(values[part.FormName()], string(buf[:n]))
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	transport := NewMailgunTransport(srv.URL, "example.com", "key-123")
+	err := transport.Send(context.Background(), "from@example.com", []string{"to@example.com"}, "subj", "body text", "", nil,
+		[]Attachment{{Name: "trace.txt", ContentType: "text/plain", Data: []byte("stack")}})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if gotPath != "/v3/example.com/messages" {
+		t.Fatalf("unexpected path: %q", gotPath)
+	}
+	if gotUser != "api" || gotPass != "key-123" {
+		t.Fatalf("unexpected basic auth: %q/%q", gotUser, gotPass)
+	}
+	if got := values["from"]; len(got) != 1 || got[0] != "from@example.com" {
+		t.Fatalf("unexpected from field: %v", got)
+	}
+	if got := values["to"]; len(got) != 1 || got[0] != "to@example.com" {
+		t.Fatalf("unexpected to field: %v", got)
+	}
+	if got := values["text"]; len(got) != 1 || got[0] != "body text" {
+		t.Fatalf("unexpected text field: %v", got)
+	}
+	if attachmentName != "trace.txt" || attachmentBody != "stack" {
+		t.Fatalf("unexpected attachment: name=%q body=%q", attachmentName, attachmentBody)
+	}
+}
+
+// TestMailgunTransportSendReturnsErrorOnNonSuccessStatus checks that a
+// non-2xx response from Mailgun surfaces as an error.
+func TestMailgunTransportSendReturnsErrorOnNonSuccessStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	transport := NewMailgunTransport(srv.URL, "example.com", "key-123")
+	err := transport.Send(context.Background(), "from@example.com", []string{"to@example.com"}, "subj", "body", "", nil, nil)
+	if err == nil {
+		t.Fatal("expected an error for a 403 response")
+	}
+}
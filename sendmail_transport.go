@@ -0,0 +1,61 @@
+package slogemail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// SendmailTransport delivers mail by piping an RFC 5322 message to a local
+// sendmail(1) binary, for hosts that have local mail delivery configured
+// but no SMTP relay to speak to.
+type SendmailTransport struct {
+	// Path to the sendmail binary. Defaults to "/usr/sbin/sendmail".
+	Path string
+}
+
+// NewSendmailTransport builds a Transport that shells out to sendmail(1).
+func NewSendmailTransport() *SendmailTransport {
+	return &SendmailTransport{Path: "/usr/sbin/sendmail"}
+}
+
+func (t *SendmailTransport) path() string {
+	if t.Path != "" {
+		return t.Path
+	}
+	return "/usr/sbin/sendmail"
+}
+
+func (t *SendmailTransport) Send(ctx context.Context, from string, to []string, subject string, textBody string, htmlBody string, headers map[string]string, attachments []Attachment) error {
+	msg, err := buildMailMsg(from, to, subject, textBody, htmlBody, headers, attachments)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, t.path(), "-t")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	if _, err := msg.WriteTo(stdin); err != nil {
+		stdin.Close()
+		return err
+	}
+	if err := stdin.Close(); err != nil {
+		return err
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("slogemail: sendmail: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
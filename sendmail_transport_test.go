@@ -0,0 +1,85 @@
+package slogemail
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeSendmail writes a small shell script standing in for sendmail(1):
+// it dumps stdin to capturePath and exits with exitCode.
+func fakeSendmail(t *testing.T, capturePath string, exitCode int) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake sendmail script requires a POSIX shell")
+	}
+	scriptPath := filepath.Join(t.TempDir(), "sendmail")
+	script := "#!/bin/sh\ncat > " + capturePath + "\nexit " + strconv.Itoa(exitCode) + "\n"
+	if err := os.WriteFile(scriptPath, []byte(script), 0o755); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return scriptPath
+}
+
+// TestSendmailTransportSendPipesRenderedMessage checks that Send writes an
+// RFC 5322 message containing the subject and body to sendmail's stdin.
+func TestSendmailTransportSendPipesRenderedMessage(t *testing.T) {
+	capturePath := filepath.Join(t.TempDir(), "captured.eml")
+	transport := &SendmailTransport{Path: fakeSendmail(t, capturePath, 0)}
+
+	err := transport.Send(context.Background(), "from@example.com", []string{"to@example.com"}, "subj", "body text", "", nil, nil)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	f, err := os.Open(capturePath)
+	if err != nil {
+		t.Fatalf("Open captured message: %v", err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+
+	msg := string(data)
+	if !strings.Contains(msg, "Subject: subj") {
+		t.Fatalf("expected rendered message to contain the subject, got: %q", msg)
+	}
+	if !strings.Contains(msg, "body text") {
+		t.Fatalf("expected rendered message to contain the body, got: %q", msg)
+	}
+}
+
+// TestSendmailTransportSendReturnsErrorOnNonZeroExit checks that a failing
+// sendmail process surfaces as an error including its stderr output.
+func TestSendmailTransportSendReturnsErrorOnNonZeroExit(t *testing.T) {
+	scriptPath := filepath.Join(t.TempDir(), "sendmail")
+	script := "#!/bin/sh\ncat > /dev/null\necho boom >&2\nexit 1\n"
+	if err := os.WriteFile(scriptPath, []byte(script), 0o755); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	transport := &SendmailTransport{Path: scriptPath}
+
+	err := transport.Send(context.Background(), "from@example.com", []string{"to@example.com"}, "subj", "body", "", nil, nil)
+	if err == nil {
+		t.Fatal("expected an error for a non-zero sendmail exit")
+	}
+	if !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected error to include sendmail's stderr, got: %v", err)
+	}
+}
+
+// TestNewSendmailTransportDefaultsPath checks that NewSendmailTransport
+// points at /usr/sbin/sendmail.
+func TestNewSendmailTransportDefaultsPath(t *testing.T) {
+	transport := NewSendmailTransport()
+	if transport.path() != "/usr/sbin/sendmail" {
+		t.Fatalf("unexpected default path: %q", transport.path())
+	}
+}
@@ -0,0 +1,122 @@
+package slogemail
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-mail"
+)
+
+func newTestClient(t *testing.T) *mail.Client {
+	t.Helper()
+	c, err := mail.NewClient("127.0.0.1", mail.WithPort(2525))
+	if err != nil {
+		t.Fatalf("mail.NewClient: %v", err)
+	}
+	return c
+}
+
+// TestSMTPPoolAcquireReusesIdleConnection checks that a non-stale idle
+// connection is handed back without consuming another slot or dialing.
+func TestSMTPPoolAcquireReusesIdleConnection(t *testing.T) {
+	p := newSMTPPool(SMTPConnectionInfo{MaxConns: 1, IdleTimeout: time.Minute})
+	client := newTestClient(t)
+	p.idle = append(p.idle, &pooledConn{client: client, lastUsed: time.Now()})
+	p.slots <- struct{}{}
+
+	got, err := p.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if got != client {
+		t.Fatalf("acquire returned a different client than the idle one")
+	}
+	if len(p.slots) != 1 {
+		t.Fatalf("acquire from idle should not change slot count, got %d", len(p.slots))
+	}
+}
+
+// TestSMTPPoolAcquireEvictsStaleIdleConnection checks that a stale idle
+// connection is closed and its slot freed before falling through to the
+// next (non-stale) idle connection.
+func TestSMTPPoolAcquireEvictsStaleIdleConnection(t *testing.T) {
+	p := newSMTPPool(SMTPConnectionInfo{MaxConns: 2, IdleTimeout: time.Millisecond})
+	fresh := newTestClient(t)
+	stale := newTestClient(t)
+	p.idle = append(p.idle, &pooledConn{client: fresh, lastUsed: time.Now()})
+	p.idle = append(p.idle, &pooledConn{client: stale, lastUsed: time.Now().Add(-time.Hour)})
+	p.slots <- struct{}{}
+	p.slots <- struct{}{}
+
+	got, err := p.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if got != fresh {
+		t.Fatalf("acquire should have evicted the stale connection and returned the fresh one")
+	}
+	if len(p.slots) != 1 {
+		t.Fatalf("evicting the stale connection should free its slot, got %d slots held", len(p.slots))
+	}
+}
+
+// TestSMTPPoolAcquireBlocksOnMaxConns checks that acquire blocks (and
+// respects ctx cancellation) once MaxConns connections are already open and
+// none are idle.
+func TestSMTPPoolAcquireBlocksOnMaxConns(t *testing.T) {
+	p := newSMTPPool(SMTPConnectionInfo{MaxConns: 1, IdleTimeout: time.Minute})
+	p.slots <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := p.acquire(ctx)
+	if err == nil {
+		t.Fatal("acquire should have blocked until ctx was done")
+	}
+}
+
+// TestSMTPPoolReleaseAndDiscard checks that release keeps a connection idle
+// while there is room, closes it and frees its slot once the idle set is
+// full, and that discard always closes and frees the slot.
+func TestSMTPPoolReleaseAndDiscard(t *testing.T) {
+	p := newSMTPPool(SMTPConnectionInfo{MaxConns: 2, IdleTimeout: time.Minute})
+
+	kept1 := newTestClient(t)
+	p.slots <- struct{}{}
+	p.release(kept1)
+	if len(p.idle) != 1 || p.idle[0].client != kept1 {
+		t.Fatalf("release should have kept the connection idle, got idle=%v", p.idle)
+	}
+	if len(p.slots) != 1 {
+		t.Fatalf("keeping a connection idle should not free its slot, got %d slots held", len(p.slots))
+	}
+
+	kept2 := newTestClient(t)
+	p.slots <- struct{}{}
+	p.release(kept2)
+	if len(p.idle) != 2 {
+		t.Fatalf("release should have kept both connections idle, got %d idle", len(p.idle))
+	}
+	if len(p.slots) != 2 {
+		t.Fatalf("keeping connections idle should not free slots, got %d slots held", len(p.slots))
+	}
+
+	// The idle set is now at MaxConns; a further release must close the
+	// connection and free its slot instead of growing the idle set.
+	overflow := newTestClient(t)
+	p.release(overflow)
+	if len(p.idle) != 2 {
+		t.Fatalf("release should not exceed MaxConns idle connections, got %d", len(p.idle))
+	}
+	if len(p.slots) != 1 {
+		t.Fatalf("overflowing release should free a slot, got %d slots held", len(p.slots))
+	}
+
+	discarded := newTestClient(t)
+	p.discard(discarded)
+	if len(p.slots) != 0 {
+		t.Fatalf("discard should free its slot, got %d slots held", len(p.slots))
+	}
+}
@@ -0,0 +1,213 @@
+package slogemail
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/wneessen/go-mail"
+)
+
+// initialSMTPBackoff is the delay before the first retry; it doubles after
+// each subsequent transient failure.
+const initialSMTPBackoff = 500 * time.Millisecond
+
+// smtpTransport is the default Transport, delivering mail via a relay SMTP
+// server using go-mail. It keeps a small pool of persistent connections
+// instead of dialing and negotiating TLS for every email, and retries
+// transient failures with exponential backoff.
+type smtpTransport struct {
+	pool        *smtpPool
+	maxRetries  int
+	sendTimeout time.Duration
+}
+
+// NewSMTPTransport builds a Transport that sends mail through a relay SMTP
+// server described by info.
+func NewSMTPTransport(info SMTPConnectionInfo) (Transport, error) {
+	return &smtpTransport{
+		pool:        newSMTPPool(info),
+		maxRetries:  info.MaxRetries,
+		sendTimeout: info.SendTimeout,
+	}, nil
+}
+
+func (t *smtpTransport) Send(ctx context.Context, from string, to []string, subject string, textBody string, htmlBody string, headers map[string]string, attachments []Attachment) error {
+	msg, err := buildMailMsg(from, to, subject, textBody, htmlBody, headers, attachments)
+	if err != nil {
+		return err
+	}
+
+	if t.sendTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.sendTimeout)
+		defer cancel()
+	}
+
+	client, err := t.pool.acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	backoff := initialSMTPBackoff
+	for attempt := 0; ; attempt++ {
+		sendErr := client.Send(msg)
+		if sendErr == nil {
+			t.pool.release(client)
+			return nil
+		}
+
+		if errors.Is(sendErr, mail.ErrNoActiveConnection) {
+			// The pooled connection died (e.g. the server hung up after
+			// IdleTimeout). Re-dial it in place and retry the send; this
+			// doesn't count against maxRetries since nothing was rejected.
+			if dialErr := client.DialWithContext(ctx); dialErr != nil {
+				t.pool.discard(client)
+				return dialErr
+			}
+			attempt--
+			continue
+		}
+
+		if isPermanentSMTPError(sendErr) || attempt >= t.maxRetries {
+			t.pool.discard(client)
+			return sendErr
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			t.pool.discard(client)
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// isPermanentSMTPError reports whether err is a 5xx SMTP reply, which
+// retrying cannot fix, as opposed to a transient network error or 4xx
+// reply. go-mail classifies this itself on the *mail.SendError it returns
+// from Send, so we defer to SendError.IsTemp rather than trying to unwrap
+// to a *textproto.Error (SendError does not implement Unwrap).
+func isPermanentSMTPError(err error) bool {
+	var sendErr *mail.SendError
+	if errors.As(err, &sendErr) {
+		return !sendErr.IsTemp()
+	}
+	return false
+}
+
+// pooledConn is one persistent SMTP client connection kept ready for reuse.
+type pooledConn struct {
+	client   *mail.Client
+	lastUsed time.Time
+}
+
+// smtpPool maintains up to MaxConns persistent *mail.Client connections to
+// a single SMTP server, handing them out for reuse across sends instead of
+// dialing and handshaking for every email. Connections idle longer than
+// IdleTimeout are discarded instead of reused.
+type smtpPool struct {
+	mu          sync.Mutex
+	info        SMTPConnectionInfo
+	idle        []*pooledConn
+	maxConns    int
+	idleTimeout time.Duration
+	// slots gates how many connections may be open at once: acquire takes
+	// a slot before dialing and blocks once maxConns are already open.
+	slots chan struct{}
+}
+
+func newSMTPPool(info SMTPConnectionInfo) *smtpPool {
+	maxConns := info.MaxConns
+	if maxConns <= 0 {
+		maxConns = 1
+	}
+	idleTimeout := info.IdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = 5 * time.Minute
+	}
+
+	return &smtpPool{
+		info:        info,
+		maxConns:    maxConns,
+		idleTimeout: idleTimeout,
+		slots:       make(chan struct{}, maxConns),
+	}
+}
+
+// acquire hands back a *mail.Client with a live SMTP connection: either one
+// reused from the idle set, or a freshly dialed one. Dialing a new
+// connection blocks until a slot frees up once MaxConns are already open.
+// Callers must return the client via release or discard when done.
+func (p *smtpPool) acquire(ctx context.Context) (*mail.Client, error) {
+	p.mu.Lock()
+	now := time.Now()
+	for len(p.idle) > 0 {
+		c := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if now.Sub(c.lastUsed) > p.idleTimeout {
+			p.mu.Unlock()
+			c.client.Close()
+			<-p.slots
+			p.mu.Lock()
+			now = time.Now()
+			continue
+		}
+		p.mu.Unlock()
+		return c.client, nil
+	}
+	p.mu.Unlock()
+
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	client, err := p.dial()
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+
+	if err := client.DialWithContext(ctx); err != nil {
+		<-p.slots
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// discard closes a connection that failed or was abandoned instead of
+// returning it to the idle set, freeing its slot for a future dial.
+func (p *smtpPool) discard(c *mail.Client) {
+	c.Close()
+	<-p.slots
+}
+
+func (p *smtpPool) release(c *mail.Client) {
+	p.mu.Lock()
+	if len(p.idle) < p.maxConns {
+		p.idle = append(p.idle, &pooledConn{client: c, lastUsed: time.Now()})
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+	c.Close()
+	<-p.slots
+}
+
+func (p *smtpPool) dial() (*mail.Client, error) {
+	opts := []mail.Option{
+		mail.WithPort(p.info.Port),
+		mail.WithUsername(p.info.Username),
+		mail.WithPassword(p.info.Password),
+	}
+	if p.info.HelloHostname != "" {
+		opts = append(opts, mail.WithHELO(p.info.HelloHostname))
+	}
+
+	return mail.NewClient(p.info.Host, opts...)
+}
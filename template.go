@@ -0,0 +1,79 @@
+package slogemail
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	texttemplate "text/template"
+	"time"
+)
+
+// EmailFormat selects which body part(s) EmailHandler renders for outgoing
+// mail.
+type EmailFormat int
+
+const (
+	// FormatPlain sends plaintext-only emails. This is the default.
+	FormatPlain EmailFormat = iota
+	// FormatHTML sends HTML-only emails, rendered from HTMLTemplate.
+	FormatHTML
+	// FormatBoth sends multipart/alternative emails carrying both a
+	// plaintext part (from TextTemplate) and an HTML part (from
+	// HTMLTemplate).
+	FormatBoth
+)
+
+// TemplateData is the value passed to HTMLTemplate and TextTemplate when
+// rendering a log record as an email body.
+type TemplateData struct {
+	Record       slog.Record
+	Level        slog.Level
+	Message      string
+	Time         time.Time
+	Attrs        map[string]any
+	Groups       []string
+	RenderedText string
+	RenderedJSON string
+}
+
+func newTemplateData(r slog.Record, groups []string, renderedText string, renderedJSON string) TemplateData {
+	attrs := make(map[string]any, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.Any()
+		return true
+	})
+
+	return TemplateData{
+		Record:       r,
+		Level:        r.Level,
+		Message:      r.Message,
+		Time:         r.Time,
+		Attrs:        attrs,
+		Groups:       groups,
+		RenderedText: renderedText,
+		RenderedJSON: renderedJSON,
+	}
+}
+
+func renderHTMLTemplate(t *htmltemplate.Template, data TemplateData) (string, error) {
+	if t == nil {
+		return "", fmt.Errorf("slogemail: HTMLTemplate must be set to use FormatHTML or FormatBoth")
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func renderTextTemplate(t *texttemplate.Template, data TemplateData) (string, error) {
+	if t == nil {
+		return "", fmt.Errorf("slogemail: TextTemplate must be set to use FormatBoth")
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
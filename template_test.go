@@ -0,0 +1,104 @@
+package slogemail
+
+import (
+	"context"
+	htmltemplate "html/template"
+	"io"
+	"log/slog"
+	"strings"
+	texttemplate "text/template"
+	"testing"
+	"time"
+)
+
+func TestNewTemplateDataPopulatesAttrs(t *testing.T) {
+	r := slog.NewRecord(time.Now(), slog.LevelWarn, "disk low", 0)
+	r.AddAttrs(slog.String("path", "/var"), slog.Int("percent", 91))
+
+	data := newTemplateData(r, []string{"svc"}, "rendered-text", "rendered-json")
+
+	if data.Message != "disk low" || data.Level != slog.LevelWarn {
+		t.Fatalf("unexpected message/level: %+v", data)
+	}
+	if data.Attrs["path"] != "/var" || data.Attrs["percent"] != int64(91) {
+		t.Fatalf("unexpected attrs: %+v", data.Attrs)
+	}
+	if len(data.Groups) != 1 || data.Groups[0] != "svc" {
+		t.Fatalf("unexpected groups: %+v", data.Groups)
+	}
+	if data.RenderedText != "rendered-text" || data.RenderedJSON != "rendered-json" {
+		t.Fatalf("unexpected rendered fields: %+v", data)
+	}
+}
+
+func TestRenderHTMLTemplateRendersAndEscapes(t *testing.T) {
+	tmpl := htmltemplate.Must(htmltemplate.New("html").Parse("<p>{{.Message}}</p>"))
+	data := newTemplateData(slog.NewRecord(time.Now(), slog.LevelError, "<script>bad</script>", 0), nil, "", "")
+
+	out, err := renderHTMLTemplate(tmpl, data)
+	if err != nil {
+		t.Fatalf("renderHTMLTemplate: %v", err)
+	}
+	if strings.Contains(out, "<script>") {
+		t.Fatalf("expected html/template to escape the message, got: %q", out)
+	}
+	if !strings.Contains(out, "&lt;script&gt;") {
+		t.Fatalf("expected escaped message in output, got: %q", out)
+	}
+}
+
+func TestRenderHTMLTemplateErrorsWithoutTemplate(t *testing.T) {
+	_, err := renderHTMLTemplate(nil, TemplateData{})
+	if err == nil {
+		t.Fatal("expected an error when HTMLTemplate is nil")
+	}
+}
+
+func TestRenderTextTemplateRendersAndErrorsWithoutTemplate(t *testing.T) {
+	tmpl := texttemplate.Must(texttemplate.New("text").Parse("msg: {{.Message}}"))
+	data := newTemplateData(slog.NewRecord(time.Now(), slog.LevelError, "boom", 0), nil, "", "")
+
+	out, err := renderTextTemplate(tmpl, data)
+	if err != nil {
+		t.Fatalf("renderTextTemplate: %v", err)
+	}
+	if out != "msg: boom" {
+		t.Fatalf("unexpected rendered text: %q", out)
+	}
+
+	if _, err := renderTextTemplate(nil, TemplateData{}); err == nil {
+		t.Fatal("expected an error when TextTemplate is nil")
+	}
+}
+
+// TestEmailHandlerFormatBothSendsMultipartTemplates is an end-to-end check
+// that FormatBoth renders both the text and HTML bodies through their
+// respective templates before handing them to the transport.
+func TestEmailHandlerFormatBothSendsMultipartTemplates(t *testing.T) {
+	transport := newRecordingTransport()
+	htmlTmpl := htmltemplate.Must(htmltemplate.New("html").Parse("<b>{{.Message}}</b>"))
+	textTmpl := texttemplate.Must(texttemplate.New("text").Parse("TEXT: {{.Message}}"))
+
+	h, err := NewHandler(io.Discard, nil, transport, EmailHandlerOpts{
+		FromAddr:     "from@example.com",
+		ToAddrs:      []string{"to@example.com"},
+		Level:        slog.LevelError,
+		Format:       FormatBoth,
+		HTMLTemplate: htmlTmpl,
+		TextTemplate: textTmpl,
+	})
+	if err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+	defer h.Shutdown(context.Background())
+
+	slog.New(h).Error("disk full")
+
+	got := transport.waitForSend(t)
+	if !strings.Contains(got, "TEXT: disk full") {
+		t.Fatalf("expected plaintext template output, got: %q", got)
+	}
+	if !strings.Contains(got, "<b>disk full</b>") {
+		t.Fatalf("expected HTML template output, got: %q", got)
+	}
+}
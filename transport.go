@@ -0,0 +1,20 @@
+package slogemail
+
+import "context"
+
+// Attachment is a file or in-memory blob to include with an outgoing email.
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Transport sends a single rendered email. Implementations decide how: a
+// relay SMTP server, an HTTP API such as Mailgun, or a local sendmail(1)
+// binary. EmailHandler is agnostic to which one it holds.
+//
+// Either textBody or htmlBody may be empty, but not both. When both are set
+// the message is sent as multipart/alternative.
+type Transport interface {
+	Send(ctx context.Context, from string, to []string, subject string, textBody string, htmlBody string, headers map[string]string, attachments []Attachment) error
+}